@@ -0,0 +1,103 @@
+package caddy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mholt/caddy/caddyhttp/httpserver"
+	"github.com/tarent/loginsrv/login"
+)
+
+// CaddyHandler plugs the login package into Caddy's middleware chain.
+type CaddyHandler struct {
+	next    httpserver.Handler
+	handler *login.LoginHandler
+	config  *login.Config
+}
+
+// ServeHTTP implements httpserver.Handler.
+func (h *CaddyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) (int, error) {
+	// When extra_jwt_issuers are configured, requests bearing a bearer
+	// token are treated as API clients of a trusted third-party IdP and
+	// never touch the cookie-based flow below.
+	// Any other Authorization scheme (Basic, ...) falls through to it
+	// instead of being rejected here.
+	if len(h.config.ExtraJwtIssuers) > 0 && strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		return h.serveBearer(w, r)
+	}
+
+	switch r.URL.Path {
+	case h.config.LoginPath:
+		return h.serveLogin(w, r)
+	case h.config.LogoutPath():
+		return h.serveLogout(w, r)
+	default:
+		h.handler.RefreshOAuthSession(w, r)
+		h.handler.RenewFromRememberMeToken(w, r)
+		return h.next.ServeHTTP(w, r)
+	}
+}
+
+func (h *CaddyHandler) serveBearer(w http.ResponseWriter, r *http.Request) (int, error) {
+	userInfo, ok, err := login.AuthenticateBearer(h.config, r)
+	if err != nil || !ok {
+		return http.StatusUnauthorized, err
+	}
+	login.SetUserInfoHeaders(r, userInfo)
+	return h.next.ServeHTTP(w, r)
+}
+
+func (h *CaddyHandler) serveLogin(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method == http.MethodGet {
+		return h.serveOAuthRedirect(w, r)
+	}
+
+	_, ok, err := h.handler.Login(w, r)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return 0, nil
+	}
+	http.Redirect(w, r, login.RedirectTarget(h.config, r), http.StatusFound)
+	return 0, nil
+}
+
+// serveOAuthRedirect handles the two GET requests of the authorization code
+// flow: the callback carrying ?code=&state=, and ?provider=name starting a
+// fresh redirect to that provider.
+func (h *CaddyHandler) serveOAuthRedirect(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.URL.Query().Get("code") != "" {
+		_, ok, err := h.handler.CompleteOAuth(w, r)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return 0, nil
+		}
+		http.Redirect(w, r, login.RedirectTarget(h.config, r), http.StatusFound)
+		return 0, nil
+	}
+
+	provider := r.URL.Query().Get("provider")
+	if provider == "" {
+		return h.next.ServeHTTP(w, r)
+	}
+
+	redirectURL, err := h.handler.StartOAuth(w, r, provider)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+	return 0, nil
+}
+
+func (h *CaddyHandler) serveLogout(w http.ResponseWriter, r *http.Request) (int, error) {
+	if err := h.handler.Logout(w, r); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	http.Redirect(w, r, login.RedirectTarget(h.config, r), http.StatusFound)
+	return 0, nil
+}
@@ -0,0 +1,310 @@
+// Package caddy wires the login package into the Caddy web server as a
+// middleware plugin.
+package caddy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mholt/caddy"
+	"github.com/mholt/caddy/caddyhttp/httpserver"
+	"github.com/tarent/loginsrv/login"
+	_ "github.com/tarent/loginsrv/login/bitbucket"
+	_ "github.com/tarent/loginsrv/login/keycloak"
+	_ "github.com/tarent/loginsrv/login/oidc"
+	_ "github.com/tarent/loginsrv/login/osiam"
+	_ "github.com/tarent/loginsrv/login/simple"
+)
+
+func init() {
+	caddy.RegisterPlugin("login", caddy.Plugin{
+		ServerType: "http",
+		Action:     setup,
+	})
+	caddy.RegisterPlugin("loginsrv", caddy.Plugin{
+		ServerType: "http",
+		Action:     setup,
+	})
+}
+
+func setup(c *caddy.Controller) error {
+	config, err := parseConfig(c)
+	if err != nil {
+		return err
+	}
+
+	handler, err := login.NewLoginHandler(config)
+	if err != nil {
+		return err
+	}
+
+	cfg := httpserver.GetConfig(c)
+	cfg.AddMiddleware(func(next httpserver.Handler) httpserver.Handler {
+		return &CaddyHandler{next: next, handler: handler, config: config}
+	})
+
+	return nil
+}
+
+func parseConfig(c *caddy.Controller) (*login.Config, error) {
+	config := login.DefaultConfig()
+
+	for c.Next() {
+		args := c.RemainingArgs()
+		switch len(args) {
+		case 0:
+			// login { ... }
+		case 1:
+			// backward compatibility: loginsrv /context { ... }
+			config.LoginPath = normalizeLoginPath(args[0])
+		default:
+			return nil, c.ArgErr()
+		}
+
+		for c.NextBlock() {
+			if err := parseOption(c, config); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(config.Backends) == 0 && len(config.Oauth) == 0 {
+		return nil, fmt.Errorf("login: at least one backend must be configured")
+	}
+
+	if config.RememberMeExpiry > 0 && len(config.SessionStore) == 0 {
+		return nil, fmt.Errorf("login: remember_me_expiry requires a session_store to be configured")
+	}
+
+	return config, nil
+}
+
+func parseOption(c *caddy.Controller, config *login.Config) error {
+	name := strings.Replace(c.Val(), "-", "_", -1)
+	args := c.RemainingArgs()
+
+	switch name {
+	case "jwt_secret":
+		return oneArg(c, args, &config.JwtSecret)
+	case "jwt_algo":
+		return oneArg(c, args, &config.JwtAlgo)
+	case "jwt_expiry":
+		return durationArg(c, args, &config.JwtExpiry)
+	case "success_url":
+		return oneArg(c, args, &config.SuccessURL)
+	case "login_path":
+		return oneArg(c, args, &config.LoginPath)
+	case "redirect":
+		return boolArg(c, args, &config.Redirect)
+	case "redirect_query_parameter":
+		return oneArg(c, args, &config.RedirectQueryParameter)
+	case "redirect_check_referer":
+		return boolArg(c, args, &config.RedirectCheckReferer)
+	case "redirect_host_file":
+		return oneArg(c, args, &config.RedirectHostFile)
+	case "redirect_whitelist_domains":
+		return parseRedirectWhitelistDomains(c, args, config)
+	case "cookie_name":
+		return oneArg(c, args, &config.CookieName)
+	case "cookie_domain":
+		return oneArg(c, args, &config.CookieDomain)
+	case "cookie_expiry":
+		return durationArg(c, args, &config.CookieExpiry)
+	case "cookie_http_only":
+		return boolArg(c, args, &config.CookieHTTPOnly)
+	case "cookie_max_size":
+		return intArg(c, args, &config.CookieMaxSize)
+	case "extra_jwt_issuers":
+		return parseExtraJwtIssuer(c, args, config)
+	case "session_store":
+		return parseSessionStore(c, args, config)
+	case "session_ttl":
+		return durationArg(c, args, &config.SessionTTL)
+	case "remember_me_expiry":
+		return durationArg(c, args, &config.RememberMeExpiry)
+	case "remember_me_cookie_name":
+		return oneArg(c, args, &config.RememberMeCookieName)
+	case "template":
+		return parseTemplate(c, args, config)
+	case "backend":
+		return parseBackend(c, args, config)
+	case "simple", "osiam":
+		return parseNamedBackend(c, name, args, config)
+	case "oidc", "keycloak", "bitbucket":
+		return parseOauthProvider(c, name, args, config)
+	default:
+		return fmt.Errorf("login: unknown property %q", c.Val())
+	}
+}
+
+// parseExtraJwtIssuer parses a repeatable
+//   extra_jwt_issuers https://accounts.google.com=audience1 [jwks_url=https://...]
+// directive into one login.IssuerSpec, appended to config.ExtraJwtIssuers.
+func parseExtraJwtIssuer(c *caddy.Controller, args []string, config *login.Config) error {
+	if len(args) == 0 || len(args) > 2 {
+		return c.ArgErr()
+	}
+
+	kv := strings.SplitN(args[0], "=", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("login: invalid extra_jwt_issuers entry %q, expected issuer=audience", args[0])
+	}
+	spec := login.IssuerSpec{Issuer: kv[0], Audience: kv[1]}
+
+	if len(args) == 2 {
+		opt := strings.SplitN(args[1], "=", 2)
+		if len(opt) != 2 || opt[0] != "jwks_url" {
+			return fmt.Errorf("login: invalid extra_jwt_issuers option %q", args[1])
+		}
+		spec.JWKSURL = opt[1]
+	}
+
+	config.ExtraJwtIssuers = append(config.ExtraJwtIssuers, spec)
+	return nil
+}
+
+// parseRedirectWhitelistDomains parses a repeatable
+//   redirect_whitelist_domains example.com,*.corp.example.com
+// directive, appending each comma separated domain to
+// config.RedirectWhitelistDomains.
+func parseRedirectWhitelistDomains(c *caddy.Controller, args []string, config *login.Config) error {
+	if len(args) != 1 {
+		return c.ArgErr()
+	}
+	config.RedirectWhitelistDomains = append(config.RedirectWhitelistDomains, strings.Split(args[0], ",")...)
+	return nil
+}
+
+func parseSessionStore(c *caddy.Controller, args []string, config *login.Config) error {
+	if len(args) == 0 {
+		return c.ArgErr()
+	}
+	backend := args[0]
+	opts, err := parseOptionsList(args[1:])
+	if err != nil {
+		return err
+	}
+	config.SessionStore = login.Options{backend: opts}
+	return nil
+}
+
+func parseTemplate(c *caddy.Controller, args []string, config *login.Config) error {
+	if len(args) != 1 {
+		return c.ArgErr()
+	}
+	path := args[0]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(httpserver.GetConfig(c).Root, path)
+	}
+	config.Template = path
+	return nil
+}
+
+func parseBackend(c *caddy.Controller, args []string, config *login.Config) error {
+	if len(args) == 0 {
+		return c.ArgErr()
+	}
+	opts, err := parseOptionsList(args)
+	if err != nil {
+		return err
+	}
+	provider, ok := opts["provider"]
+	if !ok {
+		return fmt.Errorf("login: backend requires a provider= option")
+	}
+	delete(opts, "provider")
+	config.Backends[provider] = opts
+	return nil
+}
+
+func parseNamedBackend(c *caddy.Controller, name string, args []string, config *login.Config) error {
+	opts, err := parseOptionsList(args)
+	if err != nil {
+		return err
+	}
+	config.Backends[name] = opts
+	return nil
+}
+
+func parseOauthProvider(c *caddy.Controller, name string, args []string, config *login.Config) error {
+	opts, err := parseOptionsList(args)
+	if err != nil {
+		return err
+	}
+	config.Oauth[name] = opts
+	return nil
+}
+
+// parseOptionsList parses a comma separated list of key=value options, as
+// used by the backend directives (e.g. `endpoint=...,client_id=...`).
+func parseOptionsList(args []string) (map[string]string, error) {
+	opts := map[string]string{}
+	joined := strings.Join(args, ",")
+	if joined == "" {
+		return opts, nil
+	}
+	for _, pair := range strings.Split(joined, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("login: invalid option %q, expected key=value", pair)
+		}
+		opts[kv[0]] = kv[1]
+	}
+	return opts, nil
+}
+
+func oneArg(c *caddy.Controller, args []string, dest *string) error {
+	if len(args) != 1 {
+		return c.ArgErr()
+	}
+	*dest = args[0]
+	return nil
+}
+
+func boolArg(c *caddy.Controller, args []string, dest *bool) error {
+	if len(args) != 1 {
+		return c.ArgErr()
+	}
+	v, err := strconv.ParseBool(args[0])
+	if err != nil {
+		return err
+	}
+	*dest = v
+	return nil
+}
+
+func intArg(c *caddy.Controller, args []string, dest *int) error {
+	if len(args) != 1 {
+		return c.ArgErr()
+	}
+	v, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	*dest = v
+	return nil
+}
+
+func durationArg(c *caddy.Controller, args []string, dest *time.Duration) error {
+	if len(args) != 1 {
+		return c.ArgErr()
+	}
+	d, err := time.ParseDuration(args[0])
+	if err != nil {
+		return err
+	}
+	*dest = d
+	return nil
+}
+
+// normalizeLoginPath turns the pre-v1 `loginsrv /context { ... }` context
+// argument into the modern login_path.
+func normalizeLoginPath(context string) string {
+	if context == "/" {
+		return "/login"
+	}
+	return strings.TrimSuffix(context, "/") + "/login"
+}
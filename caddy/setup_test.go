@@ -36,6 +36,9 @@ func TestSetup(t *testing.T) {
 				LoginPath:              "/login",
 				CookieName:             "jwt_token",
 				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
 				Backends: login.Options{
 					"simple": map[string]string{
 						"bob": "secret",
@@ -59,6 +62,9 @@ func TestSetup(t *testing.T) {
 							cookie_http_only false
 							cookie_domain example.com
 							cookie_expiry 23h23m
+							cookie_max_size 9000
+							session_store memory
+							session_ttl 48h
 							simple bob=secret
 							osiam endpoint=http://localhost:8080,client_id=example-client,client_secret=secret
 							}`,
@@ -77,6 +83,12 @@ func TestSetup(t *testing.T) {
 				CookieDomain:           "example.com",
 				CookieExpiry:           23*time.Hour + 23*time.Minute,
 				CookieHTTPOnly:         false,
+				CookieMaxSize:          9000,
+				SessionStore: login.Options{
+					"memory": map[string]string{},
+				},
+				SessionTTL:           48 * time.Hour,
+				RememberMeCookieName: "remember_me",
 				Backends: login.Options{
 					"simple": map[string]string{
 						"bob": "secret",
@@ -111,6 +123,9 @@ func TestSetup(t *testing.T) {
 				LoginPath:              "/context/login",
 				CookieName:             "cookiename",
 				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
 				Backends: login.Options{
 					"simple": map[string]string{
 						"bob": "secret",
@@ -140,6 +155,9 @@ func TestSetup(t *testing.T) {
 				LoginPath:              "/login",
 				CookieName:             "cookiename",
 				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
 				Backends: login.Options{
 					"simple": map[string]string{
 						"bob": "secret",
@@ -148,6 +166,236 @@ func TestSetup(t *testing.T) {
 				Oauth:       login.Options{},
 				GracePeriod: 5 * time.Second,
 			}},
+		{ // extra_jwt_issuers accepted for bearer token validation
+			input: `login {
+							simple bob=secret
+							jwt-secret jwtsecret
+							extra_jwt_issuers https://accounts.google.com=audience1
+							extra_jwt_issuers https://issuer.example.com=audience2 jwks_url=https://issuer.example.com/jwks.json
+							}`,
+			shouldErr: false,
+			config: login.Config{
+				JwtSecret:              "jwtsecret",
+				JwtAlgo:                "HS512",
+				JwtExpiry:              24 * time.Hour,
+				SuccessURL:             "/",
+				Redirect:               true,
+				RedirectQueryParameter: "backTo",
+				RedirectCheckReferer:   true,
+				LoginPath:              "/login",
+				CookieName:             "jwt_token",
+				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
+				Backends: login.Options{
+					"simple": map[string]string{
+						"bob": "secret",
+					},
+				},
+				Oauth:       login.Options{},
+				GracePeriod: 5 * time.Second,
+				ExtraJwtIssuers: []login.IssuerSpec{
+					{Issuer: "https://accounts.google.com", Audience: "audience1"},
+					{Issuer: "https://issuer.example.com", Audience: "audience2", JWKSURL: "https://issuer.example.com/jwks.json"},
+				},
+			}},
+
+		{ // oidc backend
+			input: `login {
+							jwt-secret jwtsecret
+							oidc issuer=https://idp.example.com,client_id=myclient,client_secret=mysecret,scopes=openid+profile+email
+							}`,
+			shouldErr: false,
+			config: login.Config{
+				JwtSecret:              "jwtsecret",
+				JwtAlgo:                "HS512",
+				JwtExpiry:              24 * time.Hour,
+				SuccessURL:             "/",
+				Redirect:               true,
+				RedirectQueryParameter: "backTo",
+				RedirectCheckReferer:   true,
+				LoginPath:              "/login",
+				CookieName:             "jwt_token",
+				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
+				Backends:               login.Options{},
+				Oauth: login.Options{
+					"oidc": map[string]string{
+						"issuer":        "https://idp.example.com",
+						"client_id":     "myclient",
+						"client_secret": "mysecret",
+						"scopes":        "openid+profile+email",
+					},
+				},
+				GracePeriod: 5 * time.Second,
+			}},
+
+		{ // cookie_max_size
+			input: `login {
+							simple bob=secret
+							jwt-secret jwtsecret
+							cookie_max_size 100
+							}`,
+			shouldErr: false,
+			config: login.Config{
+				JwtSecret:              "jwtsecret",
+				JwtAlgo:                "HS512",
+				JwtExpiry:              24 * time.Hour,
+				SuccessURL:             "/",
+				Redirect:               true,
+				RedirectQueryParameter: "backTo",
+				RedirectCheckReferer:   true,
+				LoginPath:              "/login",
+				CookieName:             "jwt_token",
+				CookieHTTPOnly:         true,
+				CookieMaxSize:          100,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
+				Backends: login.Options{
+					"simple": map[string]string{
+						"bob": "secret",
+					},
+				},
+				Oauth:       login.Options{},
+				GracePeriod: 5 * time.Second,
+			}},
+
+		{ // keycloak backend
+			input: `login {
+							jwt-secret jwtsecret
+							keycloak realm=myrealm,endpoint=https://idp.example.com,client_id=myclient,client_secret=mysecret,roles_claim=groups
+							}`,
+			shouldErr: false,
+			config: login.Config{
+				JwtSecret:              "jwtsecret",
+				JwtAlgo:                "HS512",
+				JwtExpiry:              24 * time.Hour,
+				SuccessURL:             "/",
+				Redirect:               true,
+				RedirectQueryParameter: "backTo",
+				RedirectCheckReferer:   true,
+				LoginPath:              "/login",
+				CookieName:             "jwt_token",
+				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
+				Backends:               login.Options{},
+				Oauth: login.Options{
+					"keycloak": map[string]string{
+						"realm":         "myrealm",
+						"endpoint":      "https://idp.example.com",
+						"client_id":     "myclient",
+						"client_secret": "mysecret",
+						"roles_claim":   "groups",
+					},
+				},
+				GracePeriod: 5 * time.Second,
+			}},
+
+		{ // bitbucket backend
+			input: `login {
+							jwt-secret jwtsecret
+							bitbucket client_id=myclient,client_secret=mysecret,workspace=myworkspace
+							}`,
+			shouldErr: false,
+			config: login.Config{
+				JwtSecret:              "jwtsecret",
+				JwtAlgo:                "HS512",
+				JwtExpiry:              24 * time.Hour,
+				SuccessURL:             "/",
+				Redirect:               true,
+				RedirectQueryParameter: "backTo",
+				RedirectCheckReferer:   true,
+				LoginPath:              "/login",
+				CookieName:             "jwt_token",
+				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
+				Backends:               login.Options{},
+				Oauth: login.Options{
+					"bitbucket": map[string]string{
+						"client_id":     "myclient",
+						"client_secret": "mysecret",
+						"workspace":     "myworkspace",
+					},
+				},
+				GracePeriod: 5 * time.Second,
+			}},
+
+		{ // redirect_whitelist_domains
+			input: `login {
+							simple bob=secret
+							jwt-secret jwtsecret
+							redirect_whitelist_domains example.com,*.corp.example.com
+							redirect_whitelist_domains other.example.com
+							}`,
+			shouldErr: false,
+			config: login.Config{
+				JwtSecret:              "jwtsecret",
+				JwtAlgo:                "HS512",
+				JwtExpiry:              24 * time.Hour,
+				SuccessURL:             "/",
+				Redirect:               true,
+				RedirectQueryParameter: "backTo",
+				RedirectCheckReferer:   true,
+				LoginPath:              "/login",
+				CookieName:             "jwt_token",
+				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
+				Backends: login.Options{
+					"simple": map[string]string{
+						"bob": "secret",
+					},
+				},
+				Oauth:       login.Options{},
+				GracePeriod: 5 * time.Second,
+				RedirectWhitelistDomains: []string{
+					"example.com", "*.corp.example.com", "other.example.com",
+				},
+			}},
+
+		{ // remember_me
+			input: `login {
+							simple bob=secret
+							jwt-secret jwtsecret
+							session_store memory
+							remember_me_expiry 720h
+							remember_me_cookie_name lta
+							}`,
+			shouldErr: false,
+			config: login.Config{
+				JwtSecret:              "jwtsecret",
+				JwtAlgo:                "HS512",
+				JwtExpiry:              24 * time.Hour,
+				SuccessURL:             "/",
+				Redirect:               true,
+				RedirectQueryParameter: "backTo",
+				RedirectCheckReferer:   true,
+				LoginPath:              "/login",
+				CookieName:             "jwt_token",
+				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionStore: login.Options{
+					"memory": map[string]string{},
+				},
+				SessionTTL: 24 * time.Hour,
+				Backends: login.Options{
+					"simple": map[string]string{
+						"bob": "secret",
+					},
+				},
+				Oauth:                login.Options{},
+				GracePeriod:          5 * time.Second,
+				RememberMeExpiry:     720 * time.Hour,
+				RememberMeCookieName: "lta",
+			}},
 
 		// error cases
 		{ // duration parse error
@@ -167,6 +415,9 @@ func TestSetup(t *testing.T) {
 				LoginPath:              "/login",
 				CookieName:             "jwt_token",
 				CookieHTTPOnly:         true,
+				CookieMaxSize:          3800,
+				SessionTTL:             24 * time.Hour,
+				RememberMeCookieName:   "remember_me",
 				Backends: login.Options{
 					"simple": map[string]string{
 						"bob": "secret",
@@ -178,6 +429,10 @@ func TestSetup(t *testing.T) {
 		{input: "login {\n}", shouldErr: true},
 		{input: "login xx yy {\n}", shouldErr: true},
 		{input: "login {\n cookie_http_only 42d \n simple bob=secret \n}", shouldErr: true},
+		{input: "login {\n cookie_max_size notanumber \n simple bob=secret \n}", shouldErr: true},
+		{input: "login {\n jwt-secret jwtsecret \n keycloak endpoint=https://idp.example.com,client_id=myclient,client_secret=mysecret \n}", shouldErr: true},
+		{input: "login {\n jwt-secret jwtsecret \n bitbucket client_id=myclient \n}", shouldErr: true},
+		{input: "login {\n simple bob=secret \n jwt-secret jwtsecret \n remember_me_expiry 720h \n}", shouldErr: true},
 		{input: "login {\n unknown property \n simple bob=secret \n}", shouldErr: true},
 		{input: "login {\n backend \n}", shouldErr: true},
 		{input: "login {\n backend provider=foo\n}", shouldErr: true},
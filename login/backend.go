@@ -0,0 +1,43 @@
+package login
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Backend authenticates a request against one configured provider (e.g.
+// "simple", "osiam", "oidc", "keycloak", "bitbucket") and returns the
+// authenticated user.
+type Backend interface {
+	Authenticate(r *http.Request) (UserInfo, bool, error)
+}
+
+// BackendFactory builds a Backend from its Caddyfile options.
+type BackendFactory func(opts map[string]string) (Backend, error)
+
+var (
+	backendFactoriesMu sync.Mutex
+	backendFactories   = map[string]BackendFactory{}
+)
+
+// RegisterBackend makes a backend provider available under name, so it can
+// be selected from the Caddyfile (e.g. `simple bob=secret`). It is expected
+// to be called from the init() of each backend's package.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendFactoriesMu.Lock()
+	defer backendFactoriesMu.Unlock()
+	backendFactories[name] = factory
+}
+
+// NewBackend looks up the factory registered under name and builds a
+// Backend from opts.
+func NewBackend(name string, opts map[string]string) (Backend, error) {
+	backendFactoriesMu.Lock()
+	factory, ok := backendFactories[name]
+	backendFactoriesMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("login: unknown backend %q", name)
+	}
+	return factory(opts)
+}
@@ -0,0 +1,98 @@
+package login
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/tarent/loginsrv/login/jwks"
+)
+
+var jwksCache = jwks.NewCache(jwks.DefaultRefreshInterval)
+var jwksDiscoveryCache = jwks.NewDiscoveryCache(jwks.DefaultRefreshInterval)
+
+// AuthenticateBearer verifies an `Authorization: Bearer <jwt>` header
+// against config.ExtraJwtIssuers, returning a UserInfo holding the token's
+// sub claim and, as Domain, its issuer. Other claims of the token are not
+// copied into the UserInfo. ok is false if the request carries no bearer
+// token.
+func AuthenticateBearer(config *Config, r *http.Request) (UserInfo, bool, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return UserInfo{}, false, nil
+	}
+	tokenString := strings.TrimPrefix(header, "Bearer ")
+
+	var spec IssuerSpec
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("login: unexpected signing method %v", t.Header["alg"])
+		}
+
+		claims, _ := t.Claims.(jwt.MapClaims)
+		iss, _ := claims["iss"].(string)
+		found, ok := findIssuer(config.ExtraJwtIssuers, iss)
+		if !ok {
+			return nil, fmt.Errorf("login: untrusted issuer %q", iss)
+		}
+		spec = found
+
+		if !audienceMatches(claims["aud"], spec.Audience) {
+			return nil, fmt.Errorf("login: unexpected audience %v", claims["aud"])
+		}
+
+		jwksURL := spec.JWKSURL
+		if jwksURL == "" {
+			discovered, err := jwksDiscoveryCache.JWKSURL(spec.Issuer)
+			if err != nil {
+				return nil, err
+			}
+			jwksURL = discovered
+			spec.JWKSURL = discovered
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		return jwksCache.Key(spec.JWKSURL, kid)
+	})
+	if err != nil {
+		return UserInfo{}, false, err
+	}
+	if !token.Valid {
+		return UserInfo{}, false, nil
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	userInfo := UserInfo{Extra: map[string]string{}}
+	if sub, ok := claims["sub"].(string); ok {
+		userInfo.Sub = sub
+	}
+	userInfo.Domain = spec.Issuer
+	return userInfo, true, nil
+}
+
+// audienceMatches reports whether wanted is among the token's aud claim,
+// which per the JWT spec may be either a single string or an array of
+// strings (used by several real-world IdPs).
+func audienceMatches(aud interface{}, wanted string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == wanted
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == wanted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func findIssuer(issuers []IssuerSpec, iss string) (IssuerSpec, bool) {
+	for _, spec := range issuers {
+		if spec.Issuer == iss {
+			return spec, true
+		}
+	}
+	return IssuerSpec{}, false
+}
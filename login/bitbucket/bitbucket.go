@@ -0,0 +1,207 @@
+// Package bitbucket implements loginsrv's "bitbucket" OAuth provider: the
+// Authorization Code + PKCE flow against Bitbucket Cloud, with claims
+// fetched from the `/2.0/user` and `/2.0/user/emails` API endpoints.
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tarent/loginsrv/login"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authURL  = "https://bitbucket.org/site/oauth2/authorize"
+	tokenURL = "https://bitbucket.org/site/oauth2/access_token"
+	apiBase  = "https://api.bitbucket.org/2.0"
+)
+
+func init() {
+	login.RegisterOAuthProvider("bitbucket", newProvider)
+}
+
+// Provider authenticates against Bitbucket Cloud. If workspaces is
+// non-empty, only members of one of the listed workspaces may authenticate.
+type Provider struct {
+	oauthConfig oauth2.Config
+	workspaces  map[string]bool
+	httpClient  *http.Client
+}
+
+func newProvider(opts map[string]string) (login.OAuthProvider, error) {
+	clientID := opts["client_id"]
+	if clientID == "" {
+		return nil, fmt.Errorf("bitbucket: client_id is required")
+	}
+	clientSecret := opts["client_secret"]
+	if clientSecret == "" {
+		return nil, fmt.Errorf("bitbucket: client_secret is required")
+	}
+
+	var workspaces map[string]bool
+	scopes := []string{"account", "email"}
+	if raw, ok := opts["workspace"]; ok && raw != "" {
+		workspaces = map[string]bool{}
+		for _, workspace := range strings.Split(raw, "+") {
+			workspaces[workspace] = true
+		}
+		// isWorkspaceMember calls /2.0/workspaces, which needs the "team"
+		// scope -- "account" alone isn't enough to list the user's
+		// workspaces.
+		scopes = append(scopes, "team")
+	}
+
+	return &Provider{
+		oauthConfig: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  authURL,
+				TokenURL: tokenURL,
+			},
+			Scopes: scopes,
+		},
+		workspaces: workspaces,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// AuthCodeURL implements login.OAuthProvider.
+func (p *Provider) AuthCodeURL(state, redirectURI string, extra map[string]string) string {
+	cfg := p.oauthConfig
+	cfg.RedirectURL = redirectURI
+	return cfg.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("code_challenge", extra["code_challenge"]),
+		oauth2.SetAuthURLParam("code_challenge_method", extra["code_challenge_method"]))
+}
+
+// Exchange implements login.OAuthProvider.
+func (p *Provider) Exchange(code, redirectURI string, extra map[string]string) (login.OAuthToken, login.UserInfo, error) {
+	cfg := p.oauthConfig
+	cfg.RedirectURL = redirectURI
+
+	token, err := cfg.Exchange(context.Background(), code,
+		oauth2.SetAuthURLParam("code_verifier", extra["code_verifier"]))
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	userInfo, err := p.fetchUserInfo(token.AccessToken)
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	return toOAuthToken(token), userInfo, nil
+}
+
+// Refresh implements login.OAuthProvider.
+func (p *Provider) Refresh(refreshToken string) (login.OAuthToken, login.UserInfo, error) {
+	if refreshToken == "" {
+		return login.OAuthToken{}, login.UserInfo{}, login.ErrRefreshNotSupported
+	}
+
+	src := p.oauthConfig.TokenSource(context.Background(), &oauth2.Token{RefreshToken: refreshToken})
+	token, err := src.Token()
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	userInfo, err := p.fetchUserInfo(token.AccessToken)
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	return toOAuthToken(token), userInfo, nil
+}
+
+func (p *Provider) fetchUserInfo(accessToken string) (login.UserInfo, error) {
+	var user struct {
+		UUID        string `json:"uuid"`
+		Username    string `json:"username"`
+		AccountID   string `json:"account_id"`
+		DisplayName string `json:"display_name"`
+	}
+	if err := p.getJSON(accessToken, apiBase+"/user", &user); err != nil {
+		return login.UserInfo{}, err
+	}
+
+	if p.workspaces != nil {
+		member, err := p.isWorkspaceMember(accessToken)
+		if err != nil {
+			return login.UserInfo{}, err
+		}
+		if !member {
+			return login.UserInfo{}, fmt.Errorf("bitbucket: user %q is not a member of an allowed workspace", user.Username)
+		}
+	}
+
+	var emails struct {
+		Values []struct {
+			Email     string `json:"email"`
+			IsPrimary bool   `json:"is_primary"`
+		} `json:"values"`
+	}
+	if err := p.getJSON(accessToken, apiBase+"/user/emails", &emails); err != nil {
+		return login.UserInfo{}, err
+	}
+
+	extra := map[string]string{"display_name": user.DisplayName}
+	for _, email := range emails.Values {
+		if email.IsPrimary {
+			extra["email"] = email.Email
+			break
+		}
+	}
+
+	return login.UserInfo{Sub: user.Username, Extra: extra}, nil
+}
+
+// isWorkspaceMember checks whether the authenticated user belongs to one of
+// the whitelisted workspaces.
+func (p *Provider) isWorkspaceMember(accessToken string) (bool, error) {
+	var workspaces struct {
+		Values []struct {
+			Slug string `json:"slug"`
+		} `json:"values"`
+	}
+	if err := p.getJSON(accessToken, apiBase+"/workspaces", &workspaces); err != nil {
+		return false, err
+	}
+	for _, workspace := range workspaces.Values {
+		if p.workspaces[workspace.Slug] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *Provider) getJSON(accessToken, url string, dest interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bitbucket: %v returned %v", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+func toOAuthToken(token *oauth2.Token) login.OAuthToken {
+	return login.OAuthToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.Expiry,
+	}
+}
@@ -0,0 +1,105 @@
+package login
+
+import (
+	"path"
+	"time"
+)
+
+// Options is a generic set of key/value configurations, keyed by backend or
+// provider name (e.g. Options{"simple": {"bob": "secret"}}).
+type Options map[string]map[string]string
+
+// Config is the configuration of the login middleware.
+type Config struct {
+	Host     string
+	Port     string
+	LogLevel string
+
+	Backends Options
+	Oauth    Options
+
+	GracePeriod time.Duration
+
+	JwtSecret string
+	JwtAlgo   string
+	JwtExpiry time.Duration
+
+	// ExtraJwtIssuers are third-party JWT issuers trusted to authenticate
+	// requests that carry an `Authorization: Bearer` header, verified via
+	// JWKS instead of JwtSecret.
+	ExtraJwtIssuers []IssuerSpec
+
+	SuccessURL             string
+	Redirect               bool
+	RedirectQueryParameter string
+	RedirectCheckReferer   bool
+	RedirectHostFile       string
+
+	// RedirectWhitelistDomains are additional domains IsValidRedirect
+	// accepts, specified inline instead of via RedirectHostFile. An entry
+	// of the form "*.foo.com" matches any direct subdomain of foo.com but
+	// not foo.com itself, unless that is listed separately.
+	RedirectWhitelistDomains []string
+
+	LoginPath string
+
+	CookieName     string
+	CookieExpiry   time.Duration
+	CookieDomain   string
+	CookieHTTPOnly bool
+
+	// CookieMaxSize is the largest value, in bytes, written into a single
+	// session cookie before it is transparently split across
+	// CookieName_0, CookieName_1, ... Most browsers silently drop
+	// cookies larger than ~4KB.
+	CookieMaxSize int
+
+	Template string
+
+	// SessionStore selects the server-side session backend ("memory",
+	// "redis" or "bolt") and its connection options. When the map is
+	// empty, loginsrv stays stateless and the JWT cookie is the only
+	// source of truth.
+	SessionStore Options
+
+	// SessionTTL is the lifetime of a server-side session record. It is
+	// independent of JwtExpiry: the JWT can be refreshed from the
+	// session for as long as the session itself is alive.
+	SessionTTL time.Duration
+
+	// RememberMeExpiry enables a long-lived "remember me" token, stored
+	// server-side and decoupled from JwtExpiry, when greater than zero.
+	// It requires SessionStore to be configured.
+	RememberMeExpiry time.Duration
+
+	// RememberMeCookieName carries the remember-me token's
+	// lookup_id:validator pair.
+	RememberMeCookieName string
+}
+
+// DefaultConfig returns a Config initialised with the project defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		JwtAlgo:                "HS512",
+		JwtExpiry:              24 * time.Hour,
+		SuccessURL:             "/",
+		Redirect:               true,
+		RedirectQueryParameter: "backTo",
+		RedirectCheckReferer:   true,
+		LoginPath:              "/login",
+		CookieName:             "jwt_token",
+		CookieHTTPOnly:         true,
+		CookieMaxSize:          3800,
+		Backends:               Options{},
+		Oauth:                  Options{},
+		GracePeriod:            5 * time.Second,
+		SessionTTL:             24 * time.Hour,
+		RememberMeCookieName:   "remember_me",
+	}
+}
+
+// LogoutPath is the request path that revokes the caller's session, derived
+// as a sibling of LoginPath (e.g. "/login" -> "/logout").
+func (c *Config) LogoutPath() string {
+	return path.Join(path.Dir(c.LoginPath), "logout")
+}
@@ -0,0 +1,134 @@
+package login
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxCookieChunks bounds how many jwt_token_N cookies writeCookie/readCookie
+// will ever create or look for, so a stray chunk from a much larger, long
+// expired token can't accumulate forever.
+const maxCookieChunks = 20
+
+// cookieExpiry returns the absolute expiry time for a cookie given the
+// configured TTL, or the zero Time for a session cookie.
+func cookieExpiry(config *Config) time.Time {
+	if config.CookieExpiry == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(config.CookieExpiry)
+}
+
+// writeCookie sets the session cookie on w, following the cookie related
+// settings in config. Values larger than config.CookieMaxSize are
+// transparently split across config.CookieName_0, _1, ... so browsers don't
+// silently drop an oversized JWT. It returns an error if value would
+// require more chunks than readCookie ever looks for.
+func writeCookie(w http.ResponseWriter, config *Config, value string) error {
+	maxSize := config.CookieMaxSize
+	if maxSize <= 0 || len(value) <= maxSize {
+		http.SetCookie(w, newCookie(config, config.CookieName, value))
+		clearChunkBoundary(w, config, 0)
+		return nil
+	}
+
+	chunks, err := chunkString(value, maxSize)
+	if err != nil {
+		return err
+	}
+	for i, chunk := range chunks {
+		http.SetCookie(w, newCookie(config, chunkCookieName(config, i), chunk))
+	}
+	clearChunkBoundary(w, config, len(chunks))
+	return nil
+}
+
+// clearChunkBoundary expires the chunk cookie immediately following the
+// last one written (index n, 0 if unchunked). readCookie reassembles
+// chunks sequentially and stops at the first one it can't read, so
+// clearing just this boundary is enough to make it ignore any
+// higher-numbered chunks left over from an earlier, larger write, without
+// having to expire the whole maxCookieChunks range on every write.
+func clearChunkBoundary(w http.ResponseWriter, config *Config, n int) {
+	if n < maxCookieChunks {
+		http.SetCookie(w, expiredCookie(config, chunkCookieName(config, n)))
+	}
+}
+
+// readCookie returns the session cookie's value, reassembling it from its
+// chunk cookies if it was split by writeCookie. Returns "" if unset.
+func readCookie(r *http.Request, config *Config) string {
+	first, err := r.Cookie(chunkCookieName(config, 0))
+	if err != nil {
+		cookie, err := r.Cookie(config.CookieName)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+
+	var value strings.Builder
+	value.WriteString(first.Value)
+	for i := 1; i < maxCookieChunks; i++ {
+		chunk, err := r.Cookie(chunkCookieName(config, i))
+		if err != nil {
+			break
+		}
+		value.WriteString(chunk.Value)
+	}
+	return value.String()
+}
+
+// deleteCookie clears the session cookie and any chunks it may have been
+// split into.
+func deleteCookie(w http.ResponseWriter, config *Config) {
+	for i := 0; i < maxCookieChunks; i++ {
+		http.SetCookie(w, expiredCookie(config, chunkCookieName(config, i)))
+	}
+	http.SetCookie(w, expiredCookie(config, config.CookieName))
+}
+
+func chunkCookieName(config *Config, i int) string {
+	return fmt.Sprintf("%s_%d", config.CookieName, i)
+}
+
+func newCookie(config *Config, name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   config.CookieDomain,
+		HttpOnly: config.CookieHTTPOnly,
+		Expires:  cookieExpiry(config),
+	}
+}
+
+func expiredCookie(config *Config, name string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		Domain:   config.CookieDomain,
+		HttpOnly: config.CookieHTTPOnly,
+		MaxAge:   -1,
+	}
+}
+
+// chunkString splits value into pieces of at most size bytes each. It
+// returns an error if that would take more than maxCookieChunks pieces,
+// since readCookie never looks beyond that many chunk cookies.
+func chunkString(value string, size int) ([]string, error) {
+	var chunks []string
+	for len(value) > size {
+		chunks = append(chunks, value[:size])
+		value = value[size:]
+	}
+	chunks = append(chunks, value)
+
+	if len(chunks) > maxCookieChunks {
+		return nil, fmt.Errorf("login: value requires %d cookie chunks, exceeding the limit of %d", len(chunks), maxCookieChunks)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,76 @@
+package login
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/stretchr/testify/assert"
+)
+
+var testCookieURL = &url.URL{Scheme: "https", Host: "example.com", Path: "/"}
+
+func TestWriteReadCookie_Chunked(t *testing.T) {
+	config := DefaultConfig()
+	config.CookieMaxSize = 3800
+
+	value := randomString(12 * 1024)
+
+	recorder := httptest.NewRecorder()
+	NoError(t, writeCookie(recorder, config, value))
+
+	Equal(t, value, readCookie(requestWithJarCookies(t, recorder), config))
+}
+
+func TestWriteReadCookie_Unchunked(t *testing.T) {
+	config := DefaultConfig()
+	value := "shortvalue"
+
+	recorder := httptest.NewRecorder()
+	NoError(t, writeCookie(recorder, config, value))
+
+	// The real cookie, plus a single expired chunk-0 cookie that keeps
+	// readCookie from picking up a stale chunked cookie from an earlier,
+	// larger write -- not the whole maxCookieChunks range.
+	Equal(t, 2, len(recorder.Result().Cookies()))
+
+	Equal(t, value, readCookie(requestWithJarCookies(t, recorder), config))
+}
+
+func TestWriteCookie_TooManyChunks(t *testing.T) {
+	config := DefaultConfig()
+	config.CookieMaxSize = 10
+
+	value := randomString(maxCookieChunks*10 + 1)
+
+	recorder := httptest.NewRecorder()
+	Error(t, writeCookie(recorder, config, value))
+}
+
+// requestWithJarCookies round-trips recorder's Set-Cookie headers through a
+// cookiejar, the same way a real browser would, so an expired chunk
+// boundary cookie is correctly dropped instead of replayed as if it were
+// still set.
+func requestWithJarCookies(t *testing.T, recorder *httptest.ResponseRecorder) *http.Request {
+	jar, err := cookiejar.New(nil)
+	NoError(t, err)
+	jar.SetCookies(testCookieURL, recorder.Result().Cookies())
+
+	r := &http.Request{Header: http.Header{}, URL: testCookieURL}
+	for _, cookie := range jar.Cookies(testCookieURL) {
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+func randomString(n int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[rand.Intn(len(alphabet))]
+	}
+	return string(b)
+}
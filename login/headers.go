@@ -0,0 +1,20 @@
+package login
+
+import "net/http"
+
+// Header names used to forward an authenticated UserInfo to the upstream.
+const (
+	HeaderUserInfoSub    = "X-Forwarded-User"
+	HeaderUserInfoDomain = "X-Forwarded-User-Domain"
+)
+
+// SetUserInfoHeaders annotates r with the headers upstream handlers use to
+// read the authenticated user, overwriting any values a client sent.
+func SetUserInfoHeaders(r *http.Request, userInfo UserInfo) {
+	r.Header.Set(HeaderUserInfoSub, userInfo.Sub)
+	if userInfo.Domain != "" {
+		r.Header.Set(HeaderUserInfoDomain, userInfo.Domain)
+	} else {
+		r.Header.Del(HeaderUserInfoDomain)
+	}
+}
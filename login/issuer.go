@@ -0,0 +1,17 @@
+package login
+
+// IssuerSpec describes one trusted external JWT issuer accepted from an
+// `Authorization: Bearer` header, in addition to loginsrv's own cookie
+// based session.
+type IssuerSpec struct {
+	// Issuer is the `iss` claim the token must carry, e.g.
+	// "https://accounts.google.com".
+	Issuer string
+
+	// Audience is the `aud` claim the token must carry.
+	Audience string
+
+	// JWKSURL overrides OIDC discovery (`<Issuer>/.well-known/openid-configuration`)
+	// with a fixed JWKS endpoint, for issuers that don't expose discovery.
+	JWKSURL string
+}
@@ -0,0 +1,216 @@
+// Package jwks fetches and caches JSON Web Key Sets, so that JWTs issued by
+// third-party identity providers can be verified without round-tripping to
+// the provider on every request.
+package jwks
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshInterval is how long a fetched key set is trusted before
+// Cache.Key fetches it again.
+const DefaultRefreshInterval = 10 * time.Minute
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type entry struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// Cache fetches and caches JWKS documents, keyed by their URL.
+type Cache struct {
+	mu              sync.Mutex
+	entries         map[string]*entry
+	refreshInterval time.Duration
+	httpClient      *http.Client
+}
+
+// NewCache creates a Cache that refreshes a JWKS document refreshInterval
+// after it was last fetched.
+func NewCache(refreshInterval time.Duration) *Cache {
+	return &Cache{
+		entries:         map[string]*entry{},
+		refreshInterval: refreshInterval,
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Key returns the RSA public key for kid from the JWKS document at jwksURL,
+// fetching or refreshing it as needed.
+func (c *Cache) Key(jwksURL, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	e, ok := c.entries[jwksURL]
+	c.mu.Unlock()
+
+	if !ok || time.Since(e.fetchedAt) > c.refreshInterval {
+		fetched, err := c.fetch(jwksURL)
+		if err != nil {
+			// Keep serving the stale key set rather than locking
+			// everyone out because the IdP is momentarily unreachable.
+			if !ok {
+				return nil, err
+			}
+		} else {
+			e = fetched
+			c.mu.Lock()
+			c.entries[jwksURL] = e
+			c.mu.Unlock()
+		}
+	}
+
+	key, ok := e.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("jwks: no key with kid %q at %s", kid, jwksURL)
+	}
+	return key, nil
+}
+
+func (c *Cache) fetch(jwksURL string) (*entry, error) {
+	resp, err := c.httpClient.Get(jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: fetching %s returned %s", jwksURL, resp.Status)
+	}
+
+	var set jsonWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		key, err := toRSAPublicKey(k.N, k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: invalid key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	return &entry{keys: keys, fetchedAt: time.Now()}, nil
+}
+
+func toRSAPublicKey(nEnc, eEnc string) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(nEnc)
+	if err != nil {
+		return nil, err
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(eEnc)
+	if err != nil {
+		return nil, err
+	}
+
+	e := 0
+	for _, b := range eBytes {
+		e = e<<8 | int(b)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: e,
+	}, nil
+}
+
+type discoveryEntry struct {
+	jwksURL   string
+	fetchedAt time.Time
+}
+
+// DiscoveryCache caches the jwks_uri resolved from an issuer's OIDC
+// discovery document, keyed by issuer, so that bearer token verification
+// doesn't have to fetch the discovery document on every request.
+type DiscoveryCache struct {
+	mu              sync.Mutex
+	entries         map[string]*discoveryEntry
+	refreshInterval time.Duration
+}
+
+// NewDiscoveryCache creates a DiscoveryCache that refreshes a discovered
+// jwks_uri refreshInterval after it was last fetched.
+func NewDiscoveryCache(refreshInterval time.Duration) *DiscoveryCache {
+	return &DiscoveryCache{
+		entries:         map[string]*discoveryEntry{},
+		refreshInterval: refreshInterval,
+	}
+}
+
+// JWKSURL returns the jwks_uri for issuer, fetching and caching its OIDC
+// discovery document as needed.
+func (c *DiscoveryCache) JWKSURL(issuer string) (string, error) {
+	c.mu.Lock()
+	e, ok := c.entries[issuer]
+	c.mu.Unlock()
+
+	if ok && time.Since(e.fetchedAt) <= c.refreshInterval {
+		return e.jwksURL, nil
+	}
+
+	jwksURL, err := DiscoverJWKSURL(issuer)
+	if err != nil {
+		if ok {
+			// Keep serving the stale URL rather than locking everyone out
+			// because the IdP is momentarily unreachable.
+			return e.jwksURL, nil
+		}
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.entries[issuer] = &discoveryEntry{jwksURL: jwksURL, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return jwksURL, nil
+}
+
+// oidcDiscoveryDoc is the subset of an OIDC discovery document loginsrv
+// cares about.
+type oidcDiscoveryDoc struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// DiscoverJWKSURL fetches issuer's OIDC discovery document and returns its
+// jwks_uri.
+func DiscoverJWKSURL(issuer string) (string, error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("jwks: discovery at %s returned %s", url, resp.Status)
+	}
+
+	var doc oidcDiscoveryDoc
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return "", err
+	}
+	if doc.JWKSURI == "" {
+		return "", fmt.Errorf("jwks: discovery document at %s has no jwks_uri", url)
+	}
+	return doc.JWKSURI, nil
+}
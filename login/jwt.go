@@ -0,0 +1,87 @@
+package login
+
+import (
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// CreateToken signs a JWT carrying userInfo's claims, valid for
+// config.JwtExpiry.
+func CreateToken(config *Config, userInfo UserInfo) (string, error) {
+	method := jwt.GetSigningMethod(config.JwtAlgo)
+	if method == nil {
+		method = jwt.SigningMethodHS512
+	}
+
+	claims := jwt.MapClaims{
+		"sub": userInfo.Sub,
+		"exp": time.Now().Add(config.JwtExpiry).Unix(),
+	}
+	if userInfo.Domain != "" {
+		claims["domain"] = userInfo.Domain
+	}
+	for k, v := range userInfo.Extra {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	return token.SignedString([]byte(config.JwtSecret))
+}
+
+// ParseToken verifies tokenString against config.JwtSecret and returns the
+// UserInfo encoded in its claims.
+func ParseToken(config *Config, tokenString string) (UserInfo, error) {
+	userInfo, _, err := parseTokenWithExpiry(config, tokenString)
+	return userInfo, err
+}
+
+// parseTokenWithExpiry is like ParseToken but also returns the token's exp
+// claim, so callers can decide whether it needs renewing.
+func parseTokenWithExpiry(config *Config, tokenString string) (UserInfo, time.Time, error) {
+	return parseClaims(config, tokenString, false)
+}
+
+// parseClaimsIgnoringExpiry is like parseTokenWithExpiry, but still returns
+// the token's claims when the only thing wrong with it is that it is
+// already expired. This lets RefreshOAuthSession recover a session whose
+// JWT expired before a request ever landed inside oauthRefreshWindow,
+// rather than treating it the same as a signature failure.
+func parseClaimsIgnoringExpiry(config *Config, tokenString string) (UserInfo, time.Time, error) {
+	return parseClaims(config, tokenString, true)
+}
+
+func parseClaims(config *Config, tokenString string, skipClaimsValidation bool) (UserInfo, time.Time, error) {
+	parser := jwt.Parser{SkipClaimsValidation: skipClaimsValidation}
+	token, err := parser.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		return []byte(config.JwtSecret), nil
+	})
+	if err != nil {
+		return UserInfo{}, time.Time{}, err
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	userInfo := UserInfo{
+		Extra: map[string]string{},
+	}
+	if sub, ok := claims["sub"].(string); ok {
+		userInfo.Sub = sub
+	}
+	if domain, ok := claims["domain"].(string); ok {
+		userInfo.Domain = domain
+	}
+	for k, v := range claims {
+		if k == "sub" || k == "exp" || k == "domain" {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			userInfo.Extra[k] = s
+		}
+	}
+
+	var expiry time.Time
+	if exp, ok := claims["exp"].(float64); ok {
+		expiry = time.Unix(int64(exp), 0)
+	}
+	return userInfo, expiry, nil
+}
@@ -0,0 +1,164 @@
+// Package keycloak implements loginsrv's "keycloak" OAuth provider: the
+// Authorization Code + PKCE flow against a Keycloak realm, with claims
+// (including realm roles) fetched from the realm's userinfo endpoint.
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tarent/loginsrv/login"
+	"golang.org/x/oauth2"
+)
+
+func init() {
+	login.RegisterOAuthProvider("keycloak", newProvider)
+}
+
+// Provider authenticates against a Keycloak realm.
+type Provider struct {
+	oauthConfig oauth2.Config
+	userInfoURL string
+	rolesClaim  string
+	httpClient  *http.Client
+}
+
+func newProvider(opts map[string]string) (login.OAuthProvider, error) {
+	realm := opts["realm"]
+	if realm == "" {
+		return nil, fmt.Errorf("keycloak: realm is required")
+	}
+	endpoint := opts["endpoint"]
+	if endpoint == "" {
+		return nil, fmt.Errorf("keycloak: endpoint is required")
+	}
+	clientID := opts["client_id"]
+	if clientID == "" {
+		return nil, fmt.Errorf("keycloak: client_id is required")
+	}
+	clientSecret := opts["client_secret"]
+	if clientSecret == "" {
+		return nil, fmt.Errorf("keycloak: client_secret is required")
+	}
+	rolesClaim := opts["roles_claim"]
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	realmURL := endpoint + "/realms/" + realm + "/protocol/openid-connect"
+
+	return &Provider{
+		oauthConfig: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  realmURL + "/auth",
+				TokenURL: realmURL + "/token",
+			},
+			Scopes: []string{"openid", "profile", "email"},
+		},
+		userInfoURL: realmURL + "/userinfo",
+		rolesClaim:  rolesClaim,
+		httpClient:  &http.Client{},
+	}, nil
+}
+
+// AuthCodeURL implements login.OAuthProvider.
+func (p *Provider) AuthCodeURL(state, redirectURI string, extra map[string]string) string {
+	cfg := p.oauthConfig
+	cfg.RedirectURL = redirectURI
+	return cfg.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("code_challenge", extra["code_challenge"]),
+		oauth2.SetAuthURLParam("code_challenge_method", extra["code_challenge_method"]))
+}
+
+// Exchange implements login.OAuthProvider.
+func (p *Provider) Exchange(code, redirectURI string, extra map[string]string) (login.OAuthToken, login.UserInfo, error) {
+	cfg := p.oauthConfig
+	cfg.RedirectURL = redirectURI
+
+	token, err := cfg.Exchange(context.Background(), code,
+		oauth2.SetAuthURLParam("code_verifier", extra["code_verifier"]))
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	userInfo, err := p.fetchUserInfo(token.AccessToken)
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	return toOAuthToken(token), userInfo, nil
+}
+
+// Refresh implements login.OAuthProvider.
+func (p *Provider) Refresh(refreshToken string) (login.OAuthToken, login.UserInfo, error) {
+	if refreshToken == "" {
+		return login.OAuthToken{}, login.UserInfo{}, login.ErrRefreshNotSupported
+	}
+
+	src := p.oauthConfig.TokenSource(context.Background(), &oauth2.Token{RefreshToken: refreshToken})
+	token, err := src.Token()
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	userInfo, err := p.fetchUserInfo(token.AccessToken)
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	return toOAuthToken(token), userInfo, nil
+}
+
+func (p *Provider) fetchUserInfo(accessToken string) (login.UserInfo, error) {
+	req, err := http.NewRequest(http.MethodGet, p.userInfoURL, nil)
+	if err != nil {
+		return login.UserInfo{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return login.UserInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return login.UserInfo{}, fmt.Errorf("keycloak: userinfo endpoint returned %v", resp.Status)
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return login.UserInfo{}, err
+	}
+
+	sub, _ := claims["sub"].(string)
+	extra := map[string]string{}
+	if email, ok := claims["email"].(string); ok {
+		extra["email"] = email
+	}
+	if roles, ok := claims[p.rolesClaim].([]interface{}); ok {
+		names := make([]string, 0, len(roles))
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				names = append(names, name)
+			}
+		}
+		extra["roles"] = strings.Join(names, ",")
+	}
+
+	return login.UserInfo{Sub: sub, Extra: extra}, nil
+}
+
+func toOAuthToken(token *oauth2.Token) login.OAuthToken {
+	return login.OAuthToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.Expiry,
+	}
+}
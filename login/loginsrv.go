@@ -0,0 +1,188 @@
+package login
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/tarent/loginsrv/login/sessionstore"
+)
+
+// LoginHandler is the core of loginsrv: it authenticates requests against
+// the configured backends and issues the session cookie, independent of
+// the web server it is embedded in (Caddy, net/http, ...).
+type LoginHandler struct {
+	config         *Config
+	backends       map[string]Backend
+	oauthProviders map[string]OAuthProvider
+	sessionStore   sessionstore.Store
+}
+
+// NewLoginHandler builds the backends configured in config.Backends and
+// config.Oauth, and, if config.SessionStore is set, the server-side session
+// store behind it.
+func NewLoginHandler(config *Config) (*LoginHandler, error) {
+	backends := map[string]Backend{}
+	for name, opts := range config.Backends {
+		backend, err := NewBackend(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		backends[name] = backend
+	}
+
+	oauthProviders := map[string]OAuthProvider{}
+	for name, opts := range config.Oauth {
+		provider, err := NewOAuthProvider(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		oauthProviders[name] = provider
+	}
+
+	var store sessionstore.Store
+	if len(config.SessionStore) > 0 {
+		for name, opts := range config.SessionStore {
+			s, err := sessionstore.New(name, opts)
+			if err != nil {
+				return nil, err
+			}
+			store = s
+			break
+		}
+	}
+
+	return &LoginHandler{
+		config:         config,
+		backends:       backends,
+		oauthProviders: oauthProviders,
+		sessionStore:   store,
+	}, nil
+}
+
+// Login tries every configured backend against r and, on success, writes
+// the session cookie to w and returns the authenticated user. A backend
+// that errors (e.g. a transient failure reaching its upstream) doesn't
+// block the others from being tried; it only surfaces if none of them
+// authenticate the request.
+func (h *LoginHandler) Login(w http.ResponseWriter, r *http.Request) (UserInfo, bool, error) {
+	var lastErr error
+	for _, backend := range h.backends {
+		userInfo, ok, err := backend.Authenticate(r)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := h.startSession(w, userInfo, "", ""); err != nil {
+			return UserInfo{}, false, err
+		}
+		if h.config.RememberMeExpiry > 0 && h.sessionStore != nil && isRememberMeRequested(r) {
+			if err := h.issueRememberMeToken(w, userInfo.Sub); err != nil {
+				return UserInfo{}, false, err
+			}
+		}
+		return userInfo, true, nil
+	}
+	return UserInfo{}, false, lastErr
+}
+
+// startSession issues the cookie for a freshly authenticated user: an
+// opaque session id when a SessionStore is configured, or a stateless JWT
+// otherwise. oauthProvider and refreshToken, if non-empty, record the OAuth
+// provider whose refresh token must be carried alongside the session so it
+// can be renewed later (see RefreshOAuthSession).
+func (h *LoginHandler) startSession(w http.ResponseWriter, userInfo UserInfo, oauthProvider, refreshToken string) error {
+	if h.sessionStore == nil {
+		token, err := CreateToken(h.config, userInfo)
+		if err != nil {
+			return err
+		}
+		if err := writeCookie(w, h.config, token); err != nil {
+			return err
+		}
+		if refreshToken != "" {
+			return h.writeRefreshTokenCookie(w, oauthProvider, refreshToken)
+		}
+		return nil
+	}
+
+	id, err := randomID(16)
+	if err != nil {
+		return err
+	}
+	ttl := h.config.SessionTTL
+	if refreshToken != "" {
+		// Keep the store record alive past its nominal TTL so a request
+		// that misses oauthRefreshWindow entirely can still renew the
+		// session from its refresh token. See sessionGracePeriod.
+		ttl += sessionGracePeriod
+	}
+	session := &sessionstore.Session{
+		ID:        id,
+		UserID:    userInfo.Sub,
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if refreshToken != "" {
+		session.Data = map[string]string{
+			"oauth_provider":      oauthProvider,
+			"oauth_refresh_token": refreshToken,
+		}
+	}
+	if err := h.sessionStore.Save(session); err != nil {
+		return err
+	}
+	return writeCookie(w, h.config, id)
+}
+
+// Authenticate validates the request's session cookie, resolving it via
+// the SessionStore when one is configured, or by verifying the JWT
+// directly otherwise. ok is false if there is no valid session.
+func (h *LoginHandler) Authenticate(r *http.Request) (UserInfo, bool) {
+	value := readCookie(r, h.config)
+	if value == "" {
+		return UserInfo{}, false
+	}
+
+	if h.sessionStore == nil {
+		userInfo, err := ParseToken(h.config, value)
+		if err != nil {
+			return UserInfo{}, false
+		}
+		return userInfo, true
+	}
+
+	session, err := h.sessionStore.Load(value)
+	if err != nil || session.Kind != "" {
+		return UserInfo{}, false
+	}
+	return UserInfo{Sub: session.UserID}, true
+}
+
+// Logout revokes the caller's server-side session, if any, and clears the
+// session cookie. It is a no-op (besides clearing the cookie) when
+// loginsrv is running stateless.
+func (h *LoginHandler) Logout(w http.ResponseWriter, r *http.Request) error {
+	defer deleteCookie(w, h.config)
+
+	if h.sessionStore == nil {
+		return nil
+	}
+	value := readCookie(r, h.config)
+	if value == "" {
+		return nil
+	}
+	return h.sessionStore.Revoke(value)
+}
+
+func randomID(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
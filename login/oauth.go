@@ -0,0 +1,238 @@
+package login
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tarent/loginsrv/login/sessionstore"
+)
+
+const (
+	oauthStateCookieName = "loginsrv_oauth_state"
+	refreshCookieSuffix  = "_rt"
+
+	// oauthRefreshWindow is how far ahead of jwt_expiry a session with a
+	// refresh token is renewed, so the browser never observes an expired
+	// JWT.
+	oauthRefreshWindow = 5 * time.Minute
+
+	// sessionGracePeriod keeps a server-side session's store record alive
+	// this long past its nominal SessionTTL, so a request that arrives
+	// within this long after the deadline (having missed
+	// oauthRefreshWindow entirely) can still use its OAuth
+	// refresh token to recover the session instead of forcing a full
+	// re-login.
+	sessionGracePeriod = oauthRefreshWindow
+)
+
+type oauthState struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Verifier string `json:"verifier"`
+}
+
+type refreshCookie struct {
+	Provider     string `json:"provider"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// StartOAuth begins the authorization code flow against the given OAuth
+// provider: it generates a PKCE verifier/challenge and CSRF state, stashes
+// them in a short lived cookie, and returns the URL to redirect the
+// browser to.
+func (h *LoginHandler) StartOAuth(w http.ResponseWriter, r *http.Request, providerName string) (string, error) {
+	provider, ok := h.oauthProviders[providerName]
+	if !ok {
+		return "", fmt.Errorf("login: unknown oauth provider %q", providerName)
+	}
+
+	state, err := randomID(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomID(32)
+	if err != nil {
+		return "", err
+	}
+
+	encoded, err := h.encryptedJSON(oauthState{Provider: providerName, State: state, Verifier: verifier})
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    encoded,
+		Path:     h.config.LoginPath,
+		HttpOnly: true,
+		MaxAge:   600,
+	})
+
+	return provider.AuthCodeURL(state, h.redirectURI(r), map[string]string{
+		"code_challenge":        codeChallenge(verifier),
+		"code_challenge_method": "S256",
+	}), nil
+}
+
+// CompleteOAuth handles the provider's redirect back to the login path: it
+// validates the CSRF state, exchanges the code for a token (using the PKCE
+// verifier), and starts a session for the authenticated user.
+func (h *LoginHandler) CompleteOAuth(w http.ResponseWriter, r *http.Request) (UserInfo, bool, error) {
+	code := r.URL.Query().Get("code")
+	state := r.URL.Query().Get("state")
+	if code == "" || state == "" {
+		return UserInfo{}, false, nil
+	}
+
+	cookie, err := r.Cookie(oauthStateCookieName)
+	if err != nil {
+		return UserInfo{}, false, nil
+	}
+	var saved oauthState
+	if err := h.decryptedJSON(cookie.Value, &saved); err != nil || saved.State != state {
+		return UserInfo{}, false, nil
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthStateCookieName, Value: "", Path: h.config.LoginPath, MaxAge: -1})
+
+	provider, ok := h.oauthProviders[saved.Provider]
+	if !ok {
+		return UserInfo{}, false, fmt.Errorf("login: unknown oauth provider %q", saved.Provider)
+	}
+
+	token, userInfo, err := provider.Exchange(code, h.redirectURI(r), map[string]string{
+		"code_verifier": saved.Verifier,
+	})
+	if err != nil {
+		return UserInfo{}, false, err
+	}
+
+	if err := h.startSession(w, userInfo, saved.Provider, token.RefreshToken); err != nil {
+		return UserInfo{}, false, err
+	}
+	return userInfo, true, nil
+}
+
+// RefreshOAuthSession renews the caller's JWT from its OIDC refresh token
+// shortly before it expires, so a long lived browser session never has to
+// go through the interactive flow again. It is a no-op if there is nothing
+// to refresh yet.
+func (h *LoginHandler) RefreshOAuthSession(w http.ResponseWriter, r *http.Request) {
+	if h.sessionStore == nil {
+		h.refreshFromCookie(w, r)
+		return
+	}
+	h.refreshFromSessionStore(w, r)
+}
+
+func (h *LoginHandler) refreshFromCookie(w http.ResponseWriter, r *http.Request) {
+	value := readCookie(r, h.config)
+	if value == "" {
+		return
+	}
+	userInfo, expiry, err := parseClaimsIgnoringExpiry(h.config, value)
+	if err != nil || !nearExpiry(expiry, oauthRefreshWindow) {
+		return
+	}
+
+	rtCookie, err := r.Cookie(h.config.CookieName + refreshCookieSuffix)
+	if err != nil {
+		return
+	}
+	var saved refreshCookie
+	if err := h.decryptedJSON(rtCookie.Value, &saved); err != nil {
+		return
+	}
+
+	h.doRefresh(w, saved.Provider, saved.RefreshToken, userInfo, nil)
+}
+
+func (h *LoginHandler) refreshFromSessionStore(w http.ResponseWriter, r *http.Request) {
+	id := readCookie(r, h.config)
+	if id == "" {
+		return
+	}
+	session, err := h.sessionStore.Load(id)
+	if err != nil || !nearExpiry(session.ExpiresAt.Add(-sessionGracePeriod), oauthRefreshWindow) {
+		return
+	}
+	refreshToken := session.Data["oauth_refresh_token"]
+	provider := session.Data["oauth_provider"]
+	if refreshToken == "" {
+		return
+	}
+	h.doRefresh(w, provider, refreshToken, UserInfo{Sub: session.UserID}, session)
+}
+
+func (h *LoginHandler) doRefresh(w http.ResponseWriter, providerName, refreshToken string, current UserInfo, session *sessionstore.Session) {
+	provider, ok := h.oauthProviders[providerName]
+	if !ok {
+		return
+	}
+	token, userInfo, err := provider.Refresh(refreshToken)
+	if err != nil {
+		return
+	}
+	if userInfo.Sub == "" {
+		userInfo.Sub = current.Sub
+	}
+
+	if session != nil {
+		session.ExpiresAt = time.Now().Add(h.config.SessionTTL + sessionGracePeriod)
+		session.Data["oauth_refresh_token"] = token.RefreshToken
+		h.sessionStore.Save(session)
+		writeCookie(w, h.config, session.ID)
+		return
+	}
+
+	h.startSession(w, userInfo, providerName, token.RefreshToken)
+}
+
+func (h *LoginHandler) writeRefreshTokenCookie(w http.ResponseWriter, providerName, refreshToken string) error {
+	encoded, err := h.encryptedJSON(refreshCookie{Provider: providerName, RefreshToken: refreshToken})
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.config.CookieName + refreshCookieSuffix,
+		Value:    encoded,
+		Path:     "/",
+		HttpOnly: true,
+	})
+	return nil
+}
+
+func (h *LoginHandler) redirectURI(r *http.Request) string {
+	scheme := "https"
+	if r.TLS == nil {
+		scheme = "http"
+	}
+	return scheme + "://" + r.Host + h.config.LoginPath
+}
+
+func (h *LoginHandler) encryptedJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return encrypt(h.config, string(data))
+}
+
+func (h *LoginHandler) decryptedJSON(encoded string, v interface{}) error {
+	plaintext, err := decrypt(h.config, encoded)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(plaintext), v)
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func nearExpiry(expiry time.Time, window time.Duration) bool {
+	return time.Until(expiry) < window
+}
@@ -0,0 +1,67 @@
+package login
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRefreshNotSupported is returned by OAuthProvider.Refresh when the
+// provider has no refresh token to exchange.
+var ErrRefreshNotSupported = errors.New("login: provider does not support refresh tokens")
+
+// OAuthToken is the result of an authorization code exchange or a refresh.
+type OAuthToken struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// OAuthProvider is implemented by redirect based login providers (OIDC,
+// Keycloak, Bitbucket, ...), as opposed to the direct-credential Backend.
+type OAuthProvider interface {
+	// AuthCodeURL returns the URL the browser is redirected to in order
+	// to start the authorization code flow. extra carries PKCE and
+	// similar per-attempt parameters (e.g. "code_challenge").
+	AuthCodeURL(state, redirectURI string, extra map[string]string) string
+
+	// Exchange trades an authorization code for a token and the
+	// authenticated user's claims. extra carries the PKCE verifier and
+	// similar per-attempt parameters.
+	Exchange(code, redirectURI string, extra map[string]string) (OAuthToken, UserInfo, error)
+
+	// Refresh exchanges a refresh token for a new token and the user's
+	// current claims. Providers that issue no refresh token return
+	// ErrRefreshNotSupported.
+	Refresh(refreshToken string) (OAuthToken, UserInfo, error)
+}
+
+// OAuthProviderFactory builds an OAuthProvider from its Caddyfile options.
+type OAuthProviderFactory func(opts map[string]string) (OAuthProvider, error)
+
+var (
+	oauthProviderFactoriesMu sync.Mutex
+	oauthProviderFactories   = map[string]OAuthProviderFactory{}
+)
+
+// RegisterOAuthProvider makes an OAuth provider available under name, so it
+// can be selected from the Caddyfile's `Oauth` block (e.g. `oidc issuer=...`).
+// It is expected to be called from the init() of each provider's package.
+func RegisterOAuthProvider(name string, factory OAuthProviderFactory) {
+	oauthProviderFactoriesMu.Lock()
+	defer oauthProviderFactoriesMu.Unlock()
+	oauthProviderFactories[name] = factory
+}
+
+// NewOAuthProvider looks up the factory registered under name and builds an
+// OAuthProvider from opts.
+func NewOAuthProvider(name string, opts map[string]string) (OAuthProvider, error) {
+	oauthProviderFactoriesMu.Lock()
+	factory, ok := oauthProviderFactories[name]
+	oauthProviderFactoriesMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("login: unknown oauth provider %q", name)
+	}
+	return factory(opts)
+}
@@ -0,0 +1,173 @@
+// Package oidc implements loginsrv's OIDC backend: the Authorization Code +
+// PKCE flow against any standards compliant OpenID Connect provider.
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	go_oidc "github.com/coreos/go-oidc"
+	"github.com/tarent/loginsrv/login"
+	"golang.org/x/oauth2"
+)
+
+func init() {
+	login.RegisterOAuthProvider("oidc", newProvider)
+}
+
+// Provider authenticates against a generic OIDC issuer. Discovery is
+// performed lazily on first use rather than at construction time, so a
+// temporarily unreachable IdP doesn't prevent loginsrv from starting up.
+type Provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	discoverOnce sync.Once
+	discoverErr  error
+	oauthConfig  *oauth2.Config
+	verifier     *go_oidc.IDTokenVerifier
+}
+
+func newProvider(opts map[string]string) (login.OAuthProvider, error) {
+	issuer := opts["issuer"]
+	if issuer == "" {
+		return nil, fmt.Errorf("oidc: issuer is required")
+	}
+	clientID := opts["client_id"]
+	if clientID == "" {
+		return nil, fmt.Errorf("oidc: client_id is required")
+	}
+	clientSecret := opts["client_secret"]
+	if clientSecret == "" {
+		return nil, fmt.Errorf("oidc: client_secret is required")
+	}
+	scopes := []string{"openid", "profile", "email"}
+	if raw, ok := opts["scopes"]; ok {
+		scopes = strings.Split(raw, "+")
+	}
+
+	return &Provider{
+		issuer:       issuer,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}, nil
+}
+
+// discover fetches the issuer's OIDC discovery document and builds the
+// oauth2.Config and ID token verifier from it, once.
+func (p *Provider) discover() error {
+	p.discoverOnce.Do(func() {
+		provider, err := go_oidc.NewProvider(context.Background(), p.issuer)
+		if err != nil {
+			p.discoverErr = fmt.Errorf("oidc: could not discover issuer %q: %w", p.issuer, err)
+			return
+		}
+		p.oauthConfig = &oauth2.Config{
+			ClientID:     p.clientID,
+			ClientSecret: p.clientSecret,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       p.scopes,
+		}
+		p.verifier = provider.Verifier(&go_oidc.Config{ClientID: p.clientID})
+	})
+	return p.discoverErr
+}
+
+// AuthCodeURL implements login.OAuthProvider.
+func (p *Provider) AuthCodeURL(state, redirectURI string, extra map[string]string) string {
+	if err := p.discover(); err != nil {
+		return ""
+	}
+	opts := []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", extra["code_challenge"]),
+		oauth2.SetAuthURLParam("code_challenge_method", extra["code_challenge_method"]),
+	}
+	cfg := *p.oauthConfig
+	cfg.RedirectURL = redirectURI
+	return cfg.AuthCodeURL(state, opts...)
+}
+
+// Exchange implements login.OAuthProvider.
+func (p *Provider) Exchange(code, redirectURI string, extra map[string]string) (login.OAuthToken, login.UserInfo, error) {
+	if err := p.discover(); err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	cfg := *p.oauthConfig
+	cfg.RedirectURL = redirectURI
+
+	token, err := cfg.Exchange(context.Background(), code,
+		oauth2.SetAuthURLParam("code_verifier", extra["code_verifier"]))
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	userInfo, err := p.verifyIDToken(token)
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	return toOAuthToken(token), userInfo, nil
+}
+
+// Refresh implements login.OAuthProvider.
+func (p *Provider) Refresh(refreshToken string) (login.OAuthToken, login.UserInfo, error) {
+	if refreshToken == "" {
+		return login.OAuthToken{}, login.UserInfo{}, login.ErrRefreshNotSupported
+	}
+	if err := p.discover(); err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	src := p.oauthConfig.TokenSource(context.Background(), &oauth2.Token{RefreshToken: refreshToken})
+	token, err := src.Token()
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	userInfo, err := p.verifyIDToken(token)
+	if err != nil {
+		return login.OAuthToken{}, login.UserInfo{}, err
+	}
+
+	return toOAuthToken(token), userInfo, nil
+}
+
+func (p *Provider) verifyIDToken(token *oauth2.Token) (login.UserInfo, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return login.UserInfo{}, fmt.Errorf("oidc: token response has no id_token")
+	}
+
+	idToken, err := p.verifier.Verify(context.Background(), rawIDToken)
+	if err != nil {
+		return login.UserInfo{}, fmt.Errorf("oidc: invalid id_token: %w", err)
+	}
+
+	var claims struct {
+		Subject string `json:"sub"`
+		Email   string `json:"email"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return login.UserInfo{}, err
+	}
+
+	return login.UserInfo{
+		Sub:    claims.Subject,
+		Domain: idToken.Issuer,
+		Extra:  map[string]string{"email": claims.Email},
+	}, nil
+}
+
+func toOAuthToken(token *oauth2.Token) login.OAuthToken {
+	return login.OAuthToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.Expiry,
+	}
+}
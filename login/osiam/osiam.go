@@ -0,0 +1,93 @@
+// Package osiam implements the "osiam" login backend, authenticating
+// against an OSIAM (https://osiam.org) identity server via the OAuth2
+// resource owner password credentials grant.
+package osiam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tarent/loginsrv/login"
+)
+
+func init() {
+	login.RegisterBackend("osiam", newBackend)
+}
+
+// Backend authenticates against an OSIAM server's token endpoint.
+type Backend struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+func newBackend(opts map[string]string) (login.Backend, error) {
+	endpoint := opts["endpoint"]
+	if endpoint == "" {
+		return nil, fmt.Errorf("osiam: endpoint is required")
+	}
+	clientID := opts["client_id"]
+	if clientID == "" {
+		return nil, fmt.Errorf("osiam: client_id is required")
+	}
+	clientSecret := opts["client_secret"]
+	if clientSecret == "" {
+		return nil, fmt.Errorf("osiam: client_secret is required")
+	}
+
+	return &Backend{
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   &http.Client{},
+	}, nil
+}
+
+// Authenticate implements login.Backend by exchanging the submitted
+// username/password for an OSIAM access token.
+func (b *Backend) Authenticate(r *http.Request) (login.UserInfo, bool, error) {
+	username := r.FormValue("username")
+	password := r.FormValue("password")
+	if username == "" || password == "" {
+		return login.UserInfo{}, false, nil
+	}
+
+	form := url.Values{
+		"grant_type": {"password"},
+		"username":   {username},
+		"password":   {password},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.endpoint+"/oauth-server/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return login.UserInfo{}, false, err
+	}
+	req.SetBasicAuth(b.clientID, b.clientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return login.UserInfo{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusBadRequest {
+		return login.UserInfo{}, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return login.UserInfo{}, false, fmt.Errorf("osiam: token endpoint returned %v", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return login.UserInfo{}, false, err
+	}
+
+	return login.UserInfo{Sub: username}, true, nil
+}
@@ -0,0 +1,103 @@
+package login
+
+import (
+	"bufio"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// IsValidRedirect reports whether target is an acceptable redirect
+// destination after login or logout: an absolute http(s) URL whose host
+// matches one of config.RedirectWhitelistDomains or a domain listed in
+// config.RedirectHostFile.
+func IsValidRedirect(config *Config, target string) (bool, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return false, nil
+	}
+
+	domains := config.RedirectWhitelistDomains
+	if config.RedirectHostFile != "" {
+		fileDomains, err := readHostFile(config.RedirectHostFile)
+		if err != nil {
+			return false, err
+		}
+		// Copy before appending: RedirectWhitelistDomains is shared across
+		// concurrent requests, and appending into it directly would race on
+		// its backing array whenever it has spare capacity.
+		domains = append(append([]string{}, domains...), fileDomains...)
+	}
+
+	host := u.Hostname()
+	for _, domain := range domains {
+		if domainMatches(domain, host) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RedirectTarget resolves where to send the browser after a successful
+// login or logout: the value of config.RedirectQueryParameter if the
+// request carries one (falling back to the Referer header when
+// config.RedirectCheckReferer is set and it doesn't), validated through
+// IsValidRedirect. config.SuccessURL is used whenever redirecting is
+// disabled, no target was supplied, or the supplied one doesn't validate.
+func RedirectTarget(config *Config, r *http.Request) string {
+	if !config.Redirect {
+		return config.SuccessURL
+	}
+
+	target := r.URL.Query().Get(config.RedirectQueryParameter)
+	if target == "" && config.RedirectCheckReferer {
+		target = r.Referer()
+	}
+	if target == "" {
+		return config.SuccessURL
+	}
+
+	if ok, err := IsValidRedirect(config, target); err != nil || !ok {
+		return config.SuccessURL
+	}
+	return target
+}
+
+// domainMatches reports whether host satisfies pattern, case-insensitively.
+// A pattern of the form "*.foo.com" matches any direct subdomain of
+// foo.com, but not foo.com itself.
+func domainMatches(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	host = strings.ToLower(host)
+
+	if suffix := strings.TrimPrefix(pattern, "*."); suffix != pattern {
+		label := strings.TrimSuffix(host, "."+suffix)
+		return label != host && label != "" && !strings.Contains(label, ".")
+	}
+	return pattern == host
+}
+
+// readHostFile reads a newline separated list of whitelisted domains,
+// ignoring blank lines and "#" comments.
+func readHostFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	return domains, scanner.Err()
+}
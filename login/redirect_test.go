@@ -0,0 +1,33 @@
+package login
+
+import (
+	"testing"
+
+	. "github.com/stretchr/testify/assert"
+)
+
+func TestIsValidRedirect(t *testing.T) {
+	config := &Config{
+		RedirectWhitelistDomains: []string{"example.com", "*.corp.example.com"},
+	}
+
+	for _, test := range []struct {
+		name   string
+		target string
+		valid  bool
+	}{
+		{"exact match", "https://example.com/path", true},
+		{"wildcard subdomain match", "https://a.corp.example.com/path", true},
+		{"wildcard does not match bare domain", "https://corp.example.com/path", false},
+		{"unlisted domain", "https://evil.com/path", false},
+		{"scheme mismatch", "ftp://example.com/path", false},
+		{"no scheme", "example.com/path", false},
+		{"case insensitive", "https://EXAMPLE.COM/path", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			valid, err := IsValidRedirect(config, test.target)
+			NoError(t, err)
+			Equal(t, test.valid, valid)
+		})
+	}
+}
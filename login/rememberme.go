@@ -0,0 +1,119 @@
+package login
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tarent/loginsrv/login/sessionstore"
+)
+
+// rememberMeValidatorHashKey is the sessionstore.Session.Data key a
+// remember-me token's validator hash is stored under.
+const rememberMeValidatorHashKey = "validator_hash"
+
+// rememberMeSessionKind tags a sessionstore.Session as a remember-me row,
+// so it can never be mistaken for a regular session by the generic
+// Authenticate path: presenting a bare lookup_id (with no validator) as
+// the ordinary session cookie must not authenticate anyone.
+const rememberMeSessionKind = "remember_me"
+
+// issueRememberMeToken creates a new remember-me token for userID, persists
+// its (lookup_id, sha256(validator)) pair via the session store, and writes
+// it to config.RememberMeCookieName as "lookup_id:validator". The
+// validator itself is never stored, so a dump of the session store cannot
+// be used to forge a token.
+func (h *LoginHandler) issueRememberMeToken(w http.ResponseWriter, userID string) error {
+	lookupID, err := randomID(16)
+	if err != nil {
+		return err
+	}
+	validator, err := randomID(32)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(h.config.RememberMeExpiry)
+	session := &sessionstore.Session{
+		ID:        lookupID,
+		UserID:    userID,
+		IssuedAt:  time.Now(),
+		ExpiresAt: expiresAt,
+		Kind:      rememberMeSessionKind,
+		Data:      map[string]string{rememberMeValidatorHashKey: hashValidator(validator)},
+	}
+	if err := h.sessionStore.Save(session); err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.config.RememberMeCookieName,
+		Value:    lookupID + ":" + validator,
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  expiresAt,
+	})
+	return nil
+}
+
+// RenewFromRememberMeToken mints a fresh session for the caller if they
+// present a valid remember-me cookie but no valid JWT/session, rotating
+// the token's validator so the presented cookie cannot be replayed. It is
+// a no-op if remember-me isn't configured or the caller is already
+// authenticated.
+func (h *LoginHandler) RenewFromRememberMeToken(w http.ResponseWriter, r *http.Request) {
+	if h.config.RememberMeExpiry <= 0 || h.sessionStore == nil {
+		return
+	}
+	if _, ok := h.Authenticate(r); ok {
+		return
+	}
+
+	cookie, err := r.Cookie(h.config.RememberMeCookieName)
+	if err != nil {
+		return
+	}
+	lookupID, validator, ok := splitRememberMeToken(cookie.Value)
+	if !ok {
+		return
+	}
+
+	session, err := h.sessionStore.Load(lookupID)
+	if err != nil || session.Kind != rememberMeSessionKind {
+		return
+	}
+	stored := session.Data[rememberMeValidatorHashKey]
+	if subtle.ConstantTimeCompare([]byte(hashValidator(validator)), []byte(stored)) != 1 {
+		return
+	}
+
+	h.sessionStore.Revoke(lookupID)
+	if err := h.issueRememberMeToken(w, session.UserID); err != nil {
+		return
+	}
+	h.startSession(w, UserInfo{Sub: session.UserID}, "", "")
+}
+
+// isRememberMeRequested reports whether the login request asked for a
+// remember-me token via a truthy "remember_me" form value.
+func isRememberMeRequested(r *http.Request) bool {
+	v, _ := strconv.ParseBool(r.FormValue("remember_me"))
+	return v
+}
+
+func splitRememberMeToken(value string) (lookupID, validator string, ok bool) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func hashValidator(validator string) string {
+	sum := sha256.Sum256([]byte(validator))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package login
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/stretchr/testify/assert"
+	"github.com/tarent/loginsrv/login/sessionstore"
+)
+
+func newRememberMeHandler() *LoginHandler {
+	config := DefaultConfig()
+	config.JwtSecret = "jwtsecret"
+	config.RememberMeExpiry = 720 * time.Hour
+	return &LoginHandler{
+		config:       config,
+		sessionStore: sessionstore.NewMemoryStore(),
+	}
+}
+
+func TestRenewFromRememberMeToken(t *testing.T) {
+	h := newRememberMeHandler()
+
+	issued := httptest.NewRecorder()
+	NoError(t, h.issueRememberMeToken(issued, "bob"))
+	cookies := issued.Result().Cookies()
+	Equal(t, 1, len(cookies))
+	original := cookies[0]
+
+	r := &http.Request{Header: http.Header{}}
+	r.AddCookie(original)
+
+	w := httptest.NewRecorder()
+	h.RenewFromRememberMeToken(w, r)
+
+	userInfo, ok := h.Authenticate(requestWithJarCookies(t, w))
+	True(t, ok)
+	Equal(t, "bob", userInfo.Sub)
+
+	var rotated *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == original.Name {
+			rotated = c
+		}
+	}
+	NotNil(t, rotated)
+	NotEqual(t, original.Value, rotated.Value)
+
+	// The old validator must no longer work after rotation.
+	reused := &http.Request{Header: http.Header{}}
+	reused.AddCookie(original)
+	replay := httptest.NewRecorder()
+	h.RenewFromRememberMeToken(replay, reused)
+	_, ok = h.Authenticate(requestWithJarCookies(t, replay))
+	False(t, ok)
+}
+
+func TestRenewFromRememberMeToken_WrongValidator(t *testing.T) {
+	h := newRememberMeHandler()
+
+	issued := httptest.NewRecorder()
+	NoError(t, h.issueRememberMeToken(issued, "bob"))
+	cookie := issued.Result().Cookies()[0]
+
+	lookupID, _, ok := splitRememberMeToken(cookie.Value)
+	True(t, ok)
+	cookie.Value = lookupID + ":wrongvalidator"
+
+	r := &http.Request{Header: http.Header{}}
+	r.AddCookie(cookie)
+
+	w := httptest.NewRecorder()
+	h.RenewFromRememberMeToken(w, r)
+
+	_, ok = h.Authenticate(requestWithJarCookies(t, w))
+	False(t, ok)
+}
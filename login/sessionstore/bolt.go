@@ -0,0 +1,87 @@
+package sessionstore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+var sessionBucket = []byte("sessions")
+
+// BoltStore is a Store backed by a local BoltDB file. It survives restarts
+// of a single loginsrv instance without requiring an external service.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) the BoltDB file at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sessionBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Close releases the underlying BoltDB file.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// Save implements Store.
+func (s *BoltStore) Save(session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionBucket).Put([]byte(session.ID), data)
+	})
+}
+
+// Load implements Store.
+func (s *BoltStore) Load(id string) (*Session, error) {
+	var session *Session
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(sessionBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		session = &Session{}
+		return json.Unmarshal(data, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		s.Revoke(id)
+		return nil, ErrNotFound
+	}
+	return session, nil
+}
+
+// Refresh implements Store.
+func (s *BoltStore) Refresh(id string, ttl time.Duration) error {
+	session, err := s.Load(id)
+	if err != nil {
+		return err
+	}
+	session.ExpiresAt = time.Now().Add(ttl)
+	return s.Save(session)
+}
+
+// Revoke implements Store.
+func (s *BoltStore) Revoke(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionBucket).Delete([]byte(id))
+	})
+}
@@ -0,0 +1,17 @@
+package sessionstore
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/stretchr/testify/assert"
+)
+
+func TestBoltStore_Suite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	store, err := NewBoltStore(path)
+	NoError(t, err)
+	defer store.Close()
+
+	testStoreSuite(t, store)
+}
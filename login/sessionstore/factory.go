@@ -0,0 +1,26 @@
+package sessionstore
+
+import "fmt"
+
+// New builds the Store configured by backend and its opts. backend must be
+// one of "memory", "redis" or "bolt".
+func New(backend string, opts map[string]string) (Store, error) {
+	switch backend {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		addr := opts["addr"]
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		return NewRedisStore(addr, opts["password"], opts["prefix"])
+	case "bolt":
+		path := opts["path"]
+		if path == "" {
+			path = "loginsrv.db"
+		}
+		return NewBoltStore(path)
+	default:
+		return nil, fmt.Errorf("sessionstore: unknown backend %q", backend)
+	}
+}
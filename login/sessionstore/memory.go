@@ -0,0 +1,70 @@
+package sessionstore
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store backed by a map. Sessions are lost on
+// restart, which makes it suitable for single-instance deployments and
+// tests, but not for a pool of loginsrv instances behind a load balancer.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: map[string]*Session{},
+	}
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *session
+	s.sessions[session.ID] = &cp
+	return nil
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if time.Now().After(session.ExpiresAt) {
+		delete(s.sessions, id)
+		return nil, ErrNotFound
+	}
+	cp := *session
+	return &cp, nil
+}
+
+// Refresh implements Store.
+func (s *MemoryStore) Refresh(id string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if time.Now().After(session.ExpiresAt) {
+		delete(s.sessions, id)
+		return ErrNotFound
+	}
+	session.ExpiresAt = time.Now().Add(ttl)
+	return nil
+}
+
+// Revoke implements Store.
+func (s *MemoryStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
@@ -0,0 +1,77 @@
+package sessionstore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// RedisStore is a Store backed by a Redis instance, suitable for sharing
+// sessions across multiple loginsrv instances.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore connects to the Redis instance at addr. prefix namespaces
+// the keys used to store sessions, so a single Redis can be shared with
+// other applications.
+func NewRedisStore(addr, password, prefix string) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+	})
+	if err := client.Ping().Err(); err != nil {
+		return nil, err
+	}
+	return &RedisStore{client: client, prefix: prefix}, nil
+}
+
+func (s *RedisStore) key(id string) string {
+	return s.prefix + id
+}
+
+// Save implements Store.
+func (s *RedisStore) Save(session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	ttl := session.ExpiresAt.Sub(time.Now())
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+	return s.client.Set(s.key(session.ID), data, ttl).Err()
+}
+
+// Load implements Store.
+func (s *RedisStore) Load(id string) (*Session, error) {
+	data, err := s.client.Get(s.key(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Refresh implements Store.
+func (s *RedisStore) Refresh(id string, ttl time.Duration) error {
+	session, err := s.Load(id)
+	if err != nil {
+		return err
+	}
+	session.ExpiresAt = time.Now().Add(ttl)
+	return s.Save(session)
+}
+
+// Revoke implements Store.
+func (s *RedisStore) Revoke(id string) error {
+	return s.client.Del(s.key(id)).Err()
+}
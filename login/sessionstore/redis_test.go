@@ -0,0 +1,23 @@
+package sessionstore
+
+import (
+	"os"
+	"testing"
+)
+
+// TestRedisStore_Suite only runs when a Redis instance is reachable, e.g. in
+// CI where LOGINSRV_TEST_REDIS_ADDR points at a throwaway container. It is
+// skipped in plain `go test ./...` runs that don't have Redis available.
+func TestRedisStore_Suite(t *testing.T) {
+	addr := os.Getenv("LOGINSRV_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("LOGINSRV_TEST_REDIS_ADDR not set, skipping redis session store test")
+	}
+
+	store, err := NewRedisStore(addr, "", "loginsrv-test:")
+	if err != nil {
+		t.Fatalf("could not connect to redis at %s: %v", addr, err)
+	}
+
+	testStoreSuite(t, store)
+}
@@ -0,0 +1,51 @@
+// Package sessionstore provides server-side storage for login sessions, so
+// that a session can be looked up, refreshed and revoked independently of
+// the JWT handed out to the browser.
+package sessionstore
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Load when no session exists for the given id,
+// or it has already expired.
+var ErrNotFound = errors.New("sessionstore: session not found")
+
+// Session is the server-side record behind an opaque session cookie.
+type Session struct {
+	ID        string
+	UserID    string
+	IP        string
+	UserAgent string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// Kind discriminates between rows sharing the same store that must
+	// never be interchangeable, e.g. a regular session vs. a remember-me
+	// token. Empty is the default, regular session.
+	Kind string
+
+	// Data carries backend specific metadata that must travel with the
+	// session, such as an OIDC refresh token.
+	Data map[string]string
+}
+
+// Store is implemented by every session backend (memory, redis, bolt, ...).
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Save persists a new session.
+	Save(session *Session) error
+
+	// Load returns the session for id, or ErrNotFound if it doesn't
+	// exist or has expired.
+	Load(id string) (*Session, error)
+
+	// Refresh extends the expiry of the session identified by id to
+	// now+ttl. It returns ErrNotFound if the session doesn't exist.
+	Refresh(id string, ttl time.Duration) error
+
+	// Revoke deletes the session identified by id. Revoking an unknown
+	// id is not an error.
+	Revoke(id string) error
+}
@@ -0,0 +1,60 @@
+package sessionstore
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/stretchr/testify/assert"
+)
+
+// testStoreSuite is run against every Store implementation to guarantee
+// they all share the same Save/Load/Refresh/Revoke semantics.
+func testStoreSuite(t *testing.T, store Store) {
+	session := &Session{
+		ID:        "session-1",
+		UserID:    "bob",
+		IP:        "127.0.0.1",
+		UserAgent: "test-agent",
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	NoError(t, store.Save(session))
+
+	loaded, err := store.Load(session.ID)
+	NoError(t, err)
+	Equal(t, session.UserID, loaded.UserID)
+	Equal(t, session.IP, loaded.IP)
+
+	NoError(t, store.Refresh(session.ID, 2*time.Hour))
+	refreshed, err := store.Load(session.ID)
+	NoError(t, err)
+	True(t, refreshed.ExpiresAt.After(session.ExpiresAt))
+
+	NoError(t, store.Revoke(session.ID))
+	_, err = store.Load(session.ID)
+	Equal(t, ErrNotFound, err)
+
+	// Revoking an unknown id is not an error.
+	NoError(t, store.Revoke("does-not-exist"))
+
+	_, err = store.Load("does-not-exist")
+	Equal(t, ErrNotFound, err)
+}
+
+func TestMemoryStore_Suite(t *testing.T) {
+	testStoreSuite(t, NewMemoryStore())
+}
+
+func TestMemoryStore_ExpiredSessionIsNotFound(t *testing.T) {
+	store := NewMemoryStore()
+	session := &Session{
+		ID:        "expired",
+		UserID:    "bob",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+	NoError(t, store.Save(session))
+
+	_, err := store.Load(session.ID)
+	Equal(t, ErrNotFound, err)
+}
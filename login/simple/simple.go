@@ -0,0 +1,35 @@
+// Package simple implements the "simple" login backend: a static table of
+// username/password pairs supplied directly in the Caddyfile, useful for
+// demos and small deployments that don't warrant a full identity provider.
+package simple
+
+import (
+	"net/http"
+
+	"github.com/tarent/loginsrv/login"
+)
+
+func init() {
+	login.RegisterBackend("simple", newBackend)
+}
+
+// Backend authenticates against a static set of credentials.
+type Backend struct {
+	credentials map[string]string
+}
+
+func newBackend(opts map[string]string) (login.Backend, error) {
+	return &Backend{credentials: opts}, nil
+}
+
+// Authenticate implements login.Backend.
+func (b *Backend) Authenticate(r *http.Request) (login.UserInfo, bool, error) {
+	username := r.FormValue("username")
+	password := r.FormValue("password")
+
+	expected, ok := b.credentials[username]
+	if !ok || expected != password {
+		return login.UserInfo{}, false, nil
+	}
+	return login.UserInfo{Sub: username}, true, nil
+}
@@ -0,0 +1,13 @@
+package login
+
+// UserInfo is the normalised result of a successful authentication,
+// independent of which backend produced it. It is serialised into the JWT
+// claims handed out to the browser.
+type UserInfo struct {
+	Sub    string `json:"sub"`
+	Domain string `json:"domain,omitempty"`
+
+	// Extra carries backend specific claims (e.g. OAuth scopes, Keycloak
+	// realm roles) that don't have a first class field here.
+	Extra map[string]string `json:"extra,omitempty"`
+}